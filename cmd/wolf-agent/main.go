@@ -5,17 +5,21 @@ import (
 	"crypto/tls"
 	"flag"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"sync/atomic"
 	"time"
 
+	"games-on-whales.github.io/direwolf/pkg/auth"
+	"games-on-whales.github.io/direwolf/pkg/carrier"
 	"games-on-whales.github.io/direwolf/pkg/controllers"
+	"games-on-whales.github.io/direwolf/pkg/proxy"
 	"games-on-whales.github.io/direwolf/pkg/util"
 	"games-on-whales.github.io/direwolf/pkg/wolfapi"
+	"golang.org/x/net/http2"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 )
 
@@ -27,6 +31,11 @@ func main() {
 	serverKeyPath := flag.String("tls-key", "server.key", "Path to server key")
 	serverPort := flag.Int("port", 443, "Port to listen on")
 	wolfSocketPath := flag.String("socket", "/var/run/wolf.sock", "Path to wolf.sock")
+	authorizationMode := flag.String("authorization-mode", "AlwaysAllow", "Comma-separated authorization chain, e.g. Node,RBAC,AlwaysAllow")
+	staticToken := flag.String("static-token", "", "Bearer token to accept when authorization-mode includes a dev fallback; empty disables it")
+	authDecisionTTL := flag.Duration("auth-decision-ttl", 30*time.Second, "How long to cache authorization decisions for a given token+resource")
+	tunnelURL := flag.String("tunnel-url", "", "If set, dial this wss:// controller URL and serve the agent over the resulting carrier tunnel instead of listening inbound")
+	disableHTTP2 := flag.Bool("disable-http2", false, "Disable HTTP/2 on both the inbound listener and the wolf.sock client")
 	klog.InitFlags(nil)
 	flag.Parse()
 
@@ -35,14 +44,23 @@ func main() {
 	klog.Info("TLS Key:", *serverKeyPath)
 	klog.Info("Port:", *serverPort)
 	klog.Info("Wolf Socket:", *wolfSocketPath)
-	client := UnixHTTPClient(*wolfSocketPath)
-
 	// Generate self-signed certificate and key
 	cert, err := util.LoadCertificates(*serverCertPath, *serverKeyPath)
 	if err != nil {
 		klog.Fatal("Failed to load certificates:", err)
 	}
 
+	authorizer, err := buildAuthorizer(*authorizationMode, *staticToken, *authDecisionTTL)
+	if err != nil {
+		klog.Fatal("Failed to build authorizer:", err)
+	}
+
+	wolfProxy := proxy.NewWolfProxy(
+		*wolfSocketPath,
+		proxy.WithHTTP2(!*disableHTTP2),
+		proxy.WithModifyResponse(auth.FilterListResponse(authorizer, auth.DefaultResourceMap)),
+	)
+
 	// Start a thread to watch for the wolf.sock to appear
 	var ready atomic.Bool
 	go func() {
@@ -103,81 +121,23 @@ func main() {
 	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
-	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+	apiHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		klog.Info("Received request:", r.Method, r.URL.Path)
 		if !ready.Load() {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			return
 		}
+		wolfProxy.ServeHTTP(w, r)
+	})
+	mux.Handle("/api/v1/", auth.Middleware(authorizer, auth.DefaultResourceMap, apiHandler))
 
-		//!TODO: Use kubernetes metric.Filter or something to implement RBAC
-		// authorization against the bearer token
-		// Proxy the request to the wolf.sock
-		url, err := url.JoinPath("http://", "wolf.sock", r.URL.Path)
-		if err != nil {
-			klog.ErrorS(err, "Failed to join URL")
-			http.Error(w, fmt.Sprintf("Failed to join URL: %v", err), http.StatusInternalServerError)
-			return
-		}
-		request, err := http.NewRequest(r.Method, url, r.Body)
-		request.Proto = r.Proto
-		request.ProtoMajor = r.ProtoMajor
-		request.ProtoMinor = r.ProtoMinor
-		request.TransferEncoding = r.TransferEncoding
-		request.ContentLength = r.ContentLength
-		if err != nil {
-			klog.ErrorS(err, "Failed to create proxy request")
-			http.Error(w, fmt.Sprintf("Failed to create proxy request: %v", err), http.StatusInternalServerError)
-			return
-		}
-		request.Header = r.Header.Clone()
-
-		// Send the request to the wolf.sock
-		klog.Info("Sending request to wolf.sock:", request.Method, request.URL.Path)
-		response, err := client.Do(request.WithContext(r.Context()))
-		if err != nil {
-			klog.ErrorS(err, "Failed to send request to wolf.sock")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		defer response.Body.Close()
-
-		// Write the response back to the client
-		for key, values := range response.Header {
-			for _, value := range values {
-				w.Header().Add(key, value)
-			}
+	if *tunnelURL != "" {
+		klog.Info("Carrier mode enabled, dialing controller:", *tunnelURL)
+		if err := carrier.StartClient(appContext, *tunnelURL, mux); err != nil {
+			klog.Fatal("Carrier tunnel failed:", err)
 		}
-		w.WriteHeader(response.StatusCode)
-		flusher, ok := w.(http.Flusher)
-		if !ok {
-			klog.Error("Flushing not supported! Aborting writing response")
-			return
-		}
-
-		// Stream response body manually. io.Copy doesn't eagerly flush
-		// which breaks SSE stream.
-		buf := make([]byte, 4096)
-		for {
-			n, err := response.Body.Read(buf)
-			if n > 0 {
-				_, writeErr := w.Write(buf[:n])
-				if writeErr != nil {
-					klog.Info("Client connection closed")
-					return
-				}
-				flusher.Flush() // Ensure immediate delivery
-			}
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				klog.ErrorS(err, "Error reading from backend")
-				return
-			}
-		}
-		klog.InfoS("Request completed", "statusCode", response.StatusCode)
-	})
+		return
+	}
 
 	// Start HTTPS server
 	server := &http.Server{
@@ -187,6 +147,15 @@ func main() {
 			Certificates: []tls.Certificate{cert},
 		},
 	}
+	if !*disableHTTP2 {
+		if err := http2.ConfigureServer(server, &http2.Server{}); err != nil {
+			klog.Fatal("Failed to configure HTTP/2:", err)
+		}
+	} else {
+		// A non-nil TLSNextProto without "h2" stops net/http from
+		// enabling HTTP/2 on its own in ListenAndServeTLS.
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
 
 	klog.Infof("Listening on port %d\n", *serverPort)
 	err = server.ListenAndServeTLS("", "")
@@ -195,12 +164,50 @@ func main() {
 	}
 }
 
-func UnixHTTPClient(sockAddr string) http.Client {
-	return http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				return net.Dial("unix", sockAddr)
-			},
-		},
+// buildAuthorizer resolves the --authorization-mode chain into a single
+// auth.Authorizer, caching its decisions for ttl. Node and RBAC both
+// resolve to the same Kubernetes-backed authorizer today - there is no
+// distinct Node identity check yet - so listing both must not double the
+// TokenReview/SubjectAccessReview calls made per request. staticToken is
+// only ever wired in alongside AlwaysAllow: it must never let a caller
+// bypass a real Node/RBAC chain, per StaticTokenAuthorizer's contract.
+func buildAuthorizer(modeFlag, staticToken string, ttl time.Duration) (auth.Authorizer, error) {
+	modes, err := auth.ParseModes(modeFlag)
+	if err != nil {
+		return nil, err
 	}
+
+	var alwaysAllow, needKube bool
+	for _, mode := range modes {
+		switch mode {
+		case auth.ModeAlwaysAllow:
+			alwaysAllow = true
+		case auth.ModeNode, auth.ModeRBAC:
+			needKube = true
+		}
+	}
+
+	var chain []auth.Authorizer
+	if needKube {
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("building in-cluster config for Kubernetes authorization: %w", err)
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("building Kubernetes client for Kubernetes authorization: %w", err)
+		}
+		chain = append(chain, auth.NewKubernetesAuthorizer(clientset))
+	}
+	if alwaysAllow {
+		if staticToken != "" {
+			chain = append(chain, auth.NewStaticTokenAuthorizer(staticToken))
+		}
+		chain = append(chain, auth.NewAlwaysAllowAuthorizer())
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("authorization-mode %q resolved to no authorizers", modeFlag)
+	}
+
+	return auth.NewCachingAuthorizer(auth.NewChainAuthorizer(chain...), ttl), nil
 }
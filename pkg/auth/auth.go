@@ -0,0 +1,269 @@
+// Package auth authenticates and authorizes requests that wolf-agent
+// proxies to wolf.sock. It follows the same shape as kube-apiserver's
+// --authorization-mode chain: a bearer token is resolved to an identity,
+// then checked against a policy mapping Wolf resources (sessions, apps,
+// pairings) to Kubernetes-style verbs, with the final decision cached for
+// a short TTL so the hot path doesn't round-trip to the API server on
+// every request.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResourceAttributes describes the access being requested, in the same
+// spirit as authorization.k8s.io/v1 ResourceAttributes.
+type ResourceAttributes struct {
+	Verb     string
+	Resource string
+	Name     string
+}
+
+// Decision is the outcome of an authorization check.
+type Decision struct {
+	Allowed bool
+	Reason  string
+}
+
+// Authorizer decides whether a bearer token is allowed to perform attrs.
+type Authorizer interface {
+	Authorize(ctx context.Context, token string, attrs ResourceAttributes) (Decision, error)
+}
+
+// Mode selects one link in the authorization chain, mirroring
+// kube-apiserver's --authorization-mode=Node,RBAC,AlwaysAllow flag.
+type Mode string
+
+const (
+	ModeNode        Mode = "Node"
+	ModeRBAC        Mode = "RBAC"
+	ModeAlwaysAllow Mode = "AlwaysAllow"
+)
+
+// ParseModes parses a comma-separated --authorization-mode value.
+func ParseModes(s string) ([]Mode, error) {
+	var modes []Mode
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		switch Mode(part) {
+		case ModeNode, ModeRBAC, ModeAlwaysAllow:
+			modes = append(modes, Mode(part))
+		default:
+			return nil, fmt.Errorf("unknown authorization mode %q", part)
+		}
+	}
+	if len(modes) == 0 {
+		return nil, fmt.Errorf("authorization-mode must not be empty")
+	}
+	return modes, nil
+}
+
+// ResourceMapping maps a path prefix under /api/v1/ to the Wolf resource
+// name used in authorization checks.
+type ResourceMapping struct {
+	PathPrefix string
+	Resource   string
+}
+
+// DefaultResourceMap covers the Wolf resources the proxy currently exposes.
+var DefaultResourceMap = []ResourceMapping{
+	{PathPrefix: "/api/v1/sessions", Resource: "sessions"},
+	{PathPrefix: "/api/v1/apps", Resource: "apps"},
+	{PathPrefix: "/api/v1/pairings", Resource: "pairings"},
+}
+
+// ResourceAttributesForRequest derives ResourceAttributes from an inbound
+// HTTP request using mappings. It returns ok=false if no mapping matches,
+// in which case callers should deny the request.
+func ResourceAttributesForRequest(r *http.Request, mappings []ResourceMapping) (ResourceAttributes, bool) {
+	for _, m := range mappings {
+		// Require a path boundary after the prefix so "/api/v1/sessions"
+		// doesn't also match "/api/v1/sessionsextra/123" as resource
+		// "sessions" with name "extra/123".
+		if r.URL.Path == m.PathPrefix || strings.HasPrefix(r.URL.Path, m.PathPrefix+"/") {
+			return ResourceAttributes{
+				Verb:     verbForMethod(r.Method),
+				Resource: m.Resource,
+				Name:     strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, m.PathPrefix), "/"),
+			}, true
+		}
+	}
+	return ResourceAttributes{}, false
+}
+
+func verbForMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return "get"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return strings.ToLower(method)
+	}
+}
+
+// BearerToken extracts the bearer token from an Authorization header, if
+// any.
+func BearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(h, prefix), true
+}
+
+// cacheEntry is a TTL-bounded authorization decision.
+type cacheEntry struct {
+	decision Decision
+	expires  time.Time
+}
+
+// CachingAuthorizer wraps an Authorizer and caches its decisions for ttl,
+// keyed by token+verb+resource+name, so that repeated requests (e.g. SSE
+// polling, session heartbeats) don't each pay for a TokenReview +
+// SubjectAccessReview round trip.
+type CachingAuthorizer struct {
+	next Authorizer
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewCachingAuthorizer wraps next with a decision cache of the given TTL
+// and, when ttl is positive, starts a janitor goroutine that sweeps
+// expired entries every ttl.
+// Without it, a long-running agent would accumulate one permanent cache
+// entry per distinct token/verb/resource/name it has ever seen - Name is
+// typically a per-request session/app/pairing ID, so that's an unbounded
+// leak for a daemon meant to run indefinitely.
+func NewCachingAuthorizer(next Authorizer, ttl time.Duration) *CachingAuthorizer {
+	c := &CachingAuthorizer{
+		next:  next,
+		ttl:   ttl,
+		cache: make(map[string]cacheEntry),
+	}
+	if ttl > 0 {
+		go c.runJanitor()
+	}
+	return c
+}
+
+func (c *CachingAuthorizer) Authorize(ctx context.Context, token string, attrs ResourceAttributes) (Decision, error) {
+	key := strings.Join([]string{token, attrs.Verb, attrs.Resource, attrs.Name}, "/")
+
+	c.mu.Lock()
+	entry, ok := c.cache[key]
+	if ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.decision, nil
+	}
+	if ok {
+		delete(c.cache, key)
+	}
+	c.mu.Unlock()
+
+	decision, err := c.next.Authorize(ctx, token, attrs)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	c.mu.Lock()
+	c.cache[key] = cacheEntry{decision: decision, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return decision, nil
+}
+
+// runJanitor evicts expired cache entries every ttl until the process
+// exits. CachingAuthorizer instances live for the lifetime of the agent
+// process, so there's no corresponding stop signal, mirroring the
+// fire-and-forget background goroutines already used elsewhere in
+// wolf-agent (e.g. the wolf.sock readiness watcher in main).
+func (c *CachingAuthorizer) runJanitor() {
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		c.mu.Lock()
+		for key, entry := range c.cache {
+			if now.After(entry.expires) {
+				delete(c.cache, key)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// ChainAuthorizer tries each Authorizer in order and returns the first
+// Allowed decision, mirroring kube-apiserver's --authorization-mode chain
+// (e.g. Node,RBAC,AlwaysAllow). If none allow, the last deny decision is
+// returned.
+type ChainAuthorizer struct {
+	chain []Authorizer
+}
+
+// NewChainAuthorizer builds a ChainAuthorizer from authorizers, evaluated
+// in order.
+func NewChainAuthorizer(authorizers ...Authorizer) *ChainAuthorizer {
+	return &ChainAuthorizer{chain: authorizers}
+}
+
+func (c *ChainAuthorizer) Authorize(ctx context.Context, token string, attrs ResourceAttributes) (Decision, error) {
+	var last Decision
+	for _, authorizer := range c.chain {
+		decision, err := authorizer.Authorize(ctx, token, attrs)
+		if err != nil {
+			return Decision{}, err
+		}
+		if decision.Allowed {
+			return decision, nil
+		}
+		last = decision
+	}
+	return last, nil
+}
+
+// Middleware authenticates and authorizes each request with authz before
+// handing it to next. A request with no bearer token is passed to authz
+// as the empty-string identity rather than denied outright: AlwaysAllow
+// must actually always allow, while a Kubernetes-backed authorizer will
+// reject the empty token via TokenReview on its own. Requests for a path
+// that doesn't map to a known Wolf resource are denied regardless.
+func Middleware(authz Authorizer, mappings []ResourceMapping, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := BearerToken(r)
+
+		attrs, ok := ResourceAttributesForRequest(r, mappings)
+		if !ok {
+			http.Error(w, "unknown resource", http.StatusNotFound)
+			return
+		}
+
+		decision, err := authz.Authorize(r.Context(), token, attrs)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("authorization check failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !decision.Allowed {
+			http.Error(w, fmt.Sprintf("forbidden: %s", decision.Reason), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// requireTokenAuthorizer denies any request without a non-empty token,
+// standing in for a Kubernetes-backed authorizer in tests.
+type requireTokenAuthorizer struct{}
+
+func (requireTokenAuthorizer) Authorize(_ context.Context, token string, _ ResourceAttributes) (Decision, error) {
+	if token == "" {
+		return Decision{Allowed: false, Reason: "empty token"}, nil
+	}
+	return Decision{Allowed: true}, nil
+}
+
+func TestMiddleware_AlwaysAllowPermitsRequestsWithNoBearerToken(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Middleware(NewAlwaysAllowAuthorizer(), DefaultResourceMap, next)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/sessions", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with AlwaysAllow and no bearer token, got %d", rec.Code)
+	}
+}
+
+func TestMiddleware_DeniesMissingTokenAgainstRBACLikeAuthorizer(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Middleware(requireTokenAuthorizer{}, DefaultResourceMap, next)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/sessions", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 with no bearer token against an authorizer that requires one, got %d", rec.Code)
+	}
+}
+
+func TestMiddleware_UnknownResourceIsNotFound(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Middleware(NewAlwaysAllowAuthorizer(), DefaultResourceMap, next)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/unknown", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unmapped resource, got %d", rec.Code)
+	}
+}
+
+func TestChainAuthorizer_DeniesEmptyTokenWithoutAlwaysAllow(t *testing.T) {
+	chain := NewChainAuthorizer(requireTokenAuthorizer{})
+
+	decision, err := chain.Authorize(context.Background(), "", ResourceAttributes{Verb: "get", Resource: "sessions"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decision.Allowed {
+		t.Fatalf("expected empty token to be denied by a chain with no AlwaysAllow member")
+	}
+}
+
+func TestResourceAttributesForRequest_RequiresPathBoundary(t *testing.T) {
+	_, ok := ResourceAttributesForRequest(
+		httptest.NewRequest(http.MethodGet, "/api/v1/sessionsextra/123", nil),
+		DefaultResourceMap,
+	)
+	if ok {
+		t.Fatalf("expected /api/v1/sessionsextra/123 not to match the sessions prefix")
+	}
+
+	attrs, ok := ResourceAttributesForRequest(
+		httptest.NewRequest(http.MethodGet, "/api/v1/sessions", nil),
+		DefaultResourceMap,
+	)
+	if !ok || attrs.Resource != "sessions" || attrs.Name != "" {
+		t.Fatalf("expected the bare collection path to match sessions with no name, got ok=%v attrs=%+v", ok, attrs)
+	}
+
+	attrs, ok = ResourceAttributesForRequest(
+		httptest.NewRequest(http.MethodGet, "/api/v1/sessions/123", nil),
+		DefaultResourceMap,
+	)
+	if !ok || attrs.Resource != "sessions" || attrs.Name != "123" {
+		t.Fatalf("expected /api/v1/sessions/123 to match sessions/123, got ok=%v attrs=%+v", ok, attrs)
+	}
+}
+
+func TestCachingAuthorizer_EvictsExpiredEntries(t *testing.T) {
+	c := NewCachingAuthorizer(NewAlwaysAllowAuthorizer(), time.Millisecond)
+	attrs := ResourceAttributes{Verb: "get", Resource: "sessions", Name: "1"}
+
+	if _, err := c.Authorize(context.Background(), "tok", attrs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.mu.Lock()
+		n := len(c.cache)
+		c.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the janitor to evict the expired entry, cache still has %d entries", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
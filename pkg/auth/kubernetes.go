@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// KubernetesAuthorizer authenticates bearer tokens with the Kubernetes
+// TokenReview API and authorizes the resulting identity with
+// SubjectAccessReview, the same two calls kube-apiserver's webhook
+// authn/authz chain makes.
+type KubernetesAuthorizer struct {
+	client kubernetes.Interface
+}
+
+// NewKubernetesAuthorizer builds a KubernetesAuthorizer from an existing
+// client-go clientset, typically constructed from the in-cluster config.
+func NewKubernetesAuthorizer(client kubernetes.Interface) *KubernetesAuthorizer {
+	return &KubernetesAuthorizer{client: client}
+}
+
+func (a *KubernetesAuthorizer) Authorize(ctx context.Context, token string, attrs ResourceAttributes) (Decision, error) {
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}
+	result, err := a.client.AuthenticationV1().TokenReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return Decision{}, fmt.Errorf("token review: %w", err)
+	}
+	if !result.Status.Authenticated {
+		return Decision{Allowed: false, Reason: "token not authenticated"}, nil
+	}
+
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   result.Status.User.Username,
+			Groups: result.Status.User.Groups,
+			UID:    result.Status.User.UID,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Verb:     attrs.Verb,
+				Group:    "wolf.games-on-whales.io",
+				Resource: attrs.Resource,
+				Name:     attrs.Name,
+			},
+		},
+	}
+	sarResult, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, sar, metav1.CreateOptions{})
+	if err != nil {
+		return Decision{}, fmt.Errorf("subject access review: %w", err)
+	}
+	if !sarResult.Status.Allowed {
+		return Decision{Allowed: false, Reason: sarResult.Status.Reason}, nil
+	}
+	return Decision{Allowed: true, Reason: sarResult.Status.Reason}, nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FilterListResponse builds an httputil.ReverseProxy ModifyResponse hook
+// that applies per-item RBAC filtering to JSON array list responses, the
+// same shape as kube-apiserver's metric.Filter: a request for a collection
+// (no resource name in the path) gets each item's "name" field checked
+// against authz, and items the caller isn't allowed to "get" are dropped
+// before the response reaches the client. Non-list responses (a named
+// resource, a status other than 200 OK, a non-JSON body) pass through
+// untouched.
+func FilterListResponse(authz Authorizer, mappings []ResourceMapping) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return nil
+		}
+		if !strings.Contains(resp.Header.Get("Content-Type"), "application/json") {
+			return nil
+		}
+
+		attrs, ok := ResourceAttributesForRequest(resp.Request, mappings)
+		if !ok || attrs.Name != "" {
+			// A specific resource was named, or the path isn't one we
+			// authorize at all: nothing to filter here.
+			return nil
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("reading list response body: %w", err)
+		}
+		resp.Body.Close()
+
+		var items []map[string]any
+		if err := json.Unmarshal(body, &items); err != nil {
+			// Not a JSON array - restore the body and pass it through.
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			return nil
+		}
+
+		token, _ := BearerToken(resp.Request)
+		filtered := make([]map[string]any, 0, len(items))
+		for _, item := range items {
+			name, _ := item["name"].(string)
+			decision, err := authz.Authorize(resp.Request.Context(), token, ResourceAttributes{
+				Verb:     "get",
+				Resource: attrs.Resource,
+				Name:     name,
+			})
+			if err != nil {
+				return fmt.Errorf("filtering list item %q: %w", name, err)
+			}
+			if decision.Allowed {
+				filtered = append(filtered, item)
+			}
+		}
+
+		out, err := json.Marshal(filtered)
+		if err != nil {
+			return fmt.Errorf("marshaling filtered list response: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(out))
+		resp.ContentLength = int64(len(out))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(out)))
+		return nil
+	}
+}
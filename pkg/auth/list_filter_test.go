@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// allowNamesAuthorizer allows only the listed names, whatever the verb or
+// resource.
+type allowNamesAuthorizer struct {
+	allowed map[string]bool
+}
+
+func (a allowNamesAuthorizer) Authorize(_ context.Context, _ string, attrs ResourceAttributes) (Decision, error) {
+	if a.allowed[attrs.Name] {
+		return Decision{Allowed: true}, nil
+	}
+	return Decision{Allowed: false, Reason: "not in allow list"}, nil
+}
+
+func TestFilterListResponse_DropsDisallowedItems(t *testing.T) {
+	authz := allowNamesAuthorizer{allowed: map[string]bool{"alpha": true}}
+	filter := FilterListResponse(authz, DefaultResourceMap)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/sessions", nil)
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`[{"name":"alpha"},{"name":"beta"}]`)),
+		Request:    req,
+	}
+
+	if err := filter(resp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading filtered body: %v", err)
+	}
+	got := string(body)
+	if !strings.Contains(got, "alpha") || strings.Contains(got, "beta") {
+		t.Fatalf("expected only alpha to survive filtering, got %q", got)
+	}
+}
+
+func TestFilterListResponse_PassesThroughNamedResource(t *testing.T) {
+	authz := allowNamesAuthorizer{}
+	filter := FilterListResponse(authz, DefaultResourceMap)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/sessions/alpha", nil)
+	const original = `{"name":"alpha"}`
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(original)),
+		Request:    req,
+	}
+
+	if err := filter(resp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if string(body) != original {
+		t.Fatalf("expected a named-resource response to pass through unmodified, got %q", body)
+	}
+}
@@ -0,0 +1,37 @@
+package auth
+
+import "context"
+
+// StaticTokenAuthorizer grants access to a single, fixed bearer token. It
+// exists for local development and CI, where standing up a Kubernetes API
+// server just to exercise the proxy isn't worth it; it must never be wired
+// up outside of AlwaysAllow-equivalent dev configurations.
+type StaticTokenAuthorizer struct {
+	token string
+}
+
+// NewStaticTokenAuthorizer returns an Authorizer that allows every request
+// bearing token and denies everything else.
+func NewStaticTokenAuthorizer(token string) *StaticTokenAuthorizer {
+	return &StaticTokenAuthorizer{token: token}
+}
+
+func (a *StaticTokenAuthorizer) Authorize(_ context.Context, token string, _ ResourceAttributes) (Decision, error) {
+	if token == a.token {
+		return Decision{Allowed: true, Reason: "static token match"}, nil
+	}
+	return Decision{Allowed: false, Reason: "static token mismatch"}, nil
+}
+
+// alwaysAllowAuthorizer implements the AlwaysAllow authorization mode.
+type alwaysAllowAuthorizer struct{}
+
+// NewAlwaysAllowAuthorizer returns an Authorizer that allows every request,
+// matching kube-apiserver's AlwaysAllow mode.
+func NewAlwaysAllowAuthorizer() Authorizer {
+	return alwaysAllowAuthorizer{}
+}
+
+func (alwaysAllowAuthorizer) Authorize(_ context.Context, _ string, _ ResourceAttributes) (Decision, error) {
+	return Decision{Allowed: true, Reason: "AlwaysAllow"}, nil
+}
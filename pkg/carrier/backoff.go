@@ -0,0 +1,28 @@
+package carrier
+
+import "time"
+
+// backoff produces doubling reconnect delays bounded by [min, max], reset to
+// min after a successful session so a single long outage doesn't leave
+// future reconnects slow.
+type backoff struct {
+	min, max time.Duration
+	current  time.Duration
+}
+
+func newBackoff(min, max time.Duration) *backoff {
+	return &backoff{min: min, max: max, current: min}
+}
+
+func (b *backoff) next() time.Duration {
+	wait := b.current
+	b.current *= 2
+	if b.current > b.max {
+		b.current = b.max
+	}
+	return wait
+}
+
+func (b *backoff) reset() {
+	b.current = b.min
+}
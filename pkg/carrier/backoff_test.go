@@ -0,0 +1,36 @@
+package carrier
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff_DoublesAndCaps(t *testing.T) {
+	b := newBackoff(time.Second, 10*time.Second)
+
+	want := []time.Duration{
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second, // capped at max
+		10 * time.Second,
+	}
+	for i, w := range want {
+		if got := b.next(); got != w {
+			t.Fatalf("next() call %d: got %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestBackoff_ResetReturnsToMin(t *testing.T) {
+	b := newBackoff(time.Second, 10*time.Second)
+
+	b.next()
+	b.next()
+	b.reset()
+
+	if got := b.next(); got != time.Second {
+		t.Fatalf("next() after reset: got %v, want %v", got, time.Second)
+	}
+}
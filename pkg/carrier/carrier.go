@@ -0,0 +1,50 @@
+// Package carrier lets wolf-agent be reached when it has no routable
+// inbound address. Instead of listening for inbound connections, it dials
+// out to a controller over TLS+WebSocket and multiplexes logical
+// connections back over that single outbound socket - the same shape
+// cloudflared and chisel use to punch through NAT/firewalls. StartClient
+// is what wolf-agent itself runs; StartServer is the controller-side
+// counterpart that accepts carrier connections and hands back a mux of
+// logical streams.
+package carrier
+
+import (
+	"net/http"
+	"time"
+)
+
+// Config holds the tunable knobs shared by StartClient and StartServer.
+type Config struct {
+	// HTTPClient is used to perform the WebSocket handshake. Left nil, the
+	// default client is used.
+	HTTPClient *http.Client
+
+	// MinBackoff and MaxBackoff bound the reconnect delay after a session
+	// drops. Defaults are 1s and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Option mutates a Config.
+type Option func(*Config)
+
+// WithHTTPClient overrides the HTTP client used for the tunnel handshake,
+// e.g. to supply a custom TLS config.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) { c.HTTPClient = client }
+}
+
+// WithBackoff overrides the reconnect backoff bounds.
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Config) {
+		c.MinBackoff = min
+		c.MaxBackoff = max
+	}
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		MinBackoff: time.Second,
+		MaxBackoff: 30 * time.Second,
+	}
+}
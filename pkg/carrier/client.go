@@ -0,0 +1,70 @@
+package carrier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"k8s.io/klog/v2"
+	"nhooyr.io/websocket"
+)
+
+// StartClient dials tunnelURL, opens a yamux session over the resulting
+// WebSocket connection, and serves handler on every logical stream the
+// controller opens. It blocks until ctx is cancelled, reconnecting with
+// backoff whenever the tunnel drops.
+func StartClient(ctx context.Context, tunnelURL string, handler http.Handler, opts ...Option) error {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	bo := newBackoff(cfg.MinBackoff, cfg.MaxBackoff)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		sessionErr := runClientSession(ctx, tunnelURL, handler, cfg, bo)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		wait := bo.next()
+		klog.ErrorS(sessionErr, "carrier session ended, reconnecting", "tunnelURL", tunnelURL, "backoff", wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func runClientSession(ctx context.Context, tunnelURL string, handler http.Handler, cfg *Config, bo *backoff) error {
+	wsConn, _, err := websocket.Dial(ctx, tunnelURL, &websocket.DialOptions{
+		HTTPClient: cfg.HTTPClient,
+	})
+	if err != nil {
+		return fmt.Errorf("dial tunnel %s: %w", tunnelURL, err)
+	}
+	defer wsConn.Close(websocket.StatusNormalClosure, "carrier client shutting down")
+
+	conn := websocket.NetConn(ctx, wsConn, websocket.MessageBinary)
+	session, err := yamux.Client(conn, yamux.DefaultConfig())
+	if err != nil {
+		return fmt.Errorf("open yamux client session: %w", err)
+	}
+	defer session.Close()
+
+	klog.Info("carrier tunnel established:", tunnelURL)
+	bo.reset()
+
+	srv := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+		session.Close()
+	}()
+	return srv.Serve(session)
+}
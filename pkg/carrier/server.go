@@ -0,0 +1,49 @@
+package carrier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/yamux"
+	"k8s.io/klog/v2"
+	"nhooyr.io/websocket"
+)
+
+// StartServer upgrades an inbound carrier connection (an agent that dialed
+// in via StartClient) to a WebSocket, wraps it in a yamux server session,
+// and passes that session to acceptFn once; the logical streams the
+// agent's http.Server accepts are opened through it. It returns when
+// acceptFn returns, closing the session.
+func StartServer(w http.ResponseWriter, r *http.Request, acceptFn func(session *yamux.Session)) error {
+	wsConn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return fmt.Errorf("accept carrier websocket: %w", err)
+	}
+	defer wsConn.Close(websocket.StatusNormalClosure, "carrier server shutting down")
+
+	conn := websocket.NetConn(r.Context(), wsConn, websocket.MessageBinary)
+	session, err := yamux.Server(conn, yamux.DefaultConfig())
+	if err != nil {
+		return fmt.Errorf("open yamux server session: %w", err)
+	}
+	defer session.Close()
+
+	klog.Info("carrier agent connected:", r.RemoteAddr)
+	acceptFn(session)
+	return nil
+}
+
+// ServeOnSession runs handler against every logical stream opened within
+// session until the session closes. It's the counterpart acceptFn for the
+// common case of exposing an http.Handler to the tunnelled agent.
+func ServeOnSession(ctx context.Context, session *yamux.Session, handler http.Handler) {
+	srv := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+		session.Close()
+	}()
+	if err := srv.Serve(session); err != nil {
+		klog.ErrorS(err, "carrier session serve ended")
+	}
+}
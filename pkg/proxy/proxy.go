@@ -0,0 +1,120 @@
+// Package proxy reverse-proxies the wolf-agent /api/v1 surface to
+// wolf.sock. It replaces a hand-rolled Director+streaming loop with
+// httputil.ReverseProxy, which already gets header hop-by-hop stripping,
+// query-string preservation, and Upgrade (WebSocket) handling right -
+// the previous code dropped r.URL.RawQuery and mangled paths containing
+// ".." via url.JoinPath, and did not carry Upgrade requests through at
+// all.
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"golang.org/x/net/http2"
+)
+
+// Option configures a WolfProxy.
+type Option func(*config)
+
+type config struct {
+	useHTTP2       bool
+	modifyResponse func(*http.Response) error
+}
+
+func defaultConfig() *config {
+	return &config{useHTTP2: true}
+}
+
+// WithHTTP2 toggles h2c negotiation to wolf.sock. Enabled by default.
+func WithHTTP2(enabled bool) Option {
+	return func(c *config) { c.useHTTP2 = enabled }
+}
+
+// WithModifyResponse installs a hook run on every response before it's
+// written to the client, e.g. to filter list responses down to what the
+// caller's RBAC decision allows (mirroring kube-apiserver's metric.Filter).
+func WithModifyResponse(fn func(*http.Response) error) Option {
+	return func(c *config) { c.modifyResponse = fn }
+}
+
+// NewWolfProxy returns an http.Handler that reverse-proxies every request
+// it receives to wolf.sock over the unix socket at socket.
+func NewWolfProxy(socket string, opts ...Option) http.Handler {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	director := func(r *http.Request) {
+		r.URL.Scheme = "http"
+		r.URL.Host = "wolf.sock"
+		// Path, RawQuery, Connection and Upgrade are left exactly as the
+		// client sent them: httputil.ReverseProxy.ServeHTTP inspects
+		// outreq.Header *after* the Director runs to decide whether this
+		// is an Upgrade request and, if so, re-adds Connection/Upgrade
+		// before dialing the backend. Stripping them here as part of
+		// generic hop-by-hop cleanup (ServeHTTP does that itself, later)
+		// would make every Upgrade request look like a plain request and
+		// silently break WebSocket proxying.
+	}
+
+	return &httputil.ReverseProxy{
+		Director:       director,
+		Transport:      unixTransport(socket, cfg.useHTTP2),
+		ModifyResponse: cfg.modifyResponse,
+		FlushInterval:  -1, // flush immediately; required for SSE
+	}
+}
+
+// isUpgradeRequest reports whether r is asking to switch protocols.
+func isUpgradeRequest(r *http.Request) bool {
+	return r.Header.Get("Upgrade") != "" &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+// unixTransport builds the RoundTripper used to reach wolf.sock. With
+// useHTTP2 it negotiates h2c (HTTP/2 without TLS, since the unix socket
+// needs no transport security of its own) so that concurrent proxied
+// calls can share a single connection instead of opening one per request.
+// HTTP/2 has no Upgrade mechanism of its own (RFC 7540 forbids the
+// Upgrade header on h2 requests), so Upgrade requests are always routed
+// over a plain HTTP/1.1 unix transport regardless of useHTTP2.
+func unixTransport(socket string, useHTTP2 bool) http.RoundTripper {
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return net.Dial("unix", socket)
+	}
+	http1 := &http.Transport{DialContext: dial}
+
+	if !useHTTP2 {
+		return http1
+	}
+
+	http2Transport := &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+	}
+
+	return &upgradeAwareTransport{h2: http2Transport, h1: http1}
+}
+
+// upgradeAwareTransport dispatches Upgrade requests to an HTTP/1.1
+// transport (the only one that can hand httputil.ReverseProxy a hijackable
+// 101 response body) and everything else to the h2c transport.
+type upgradeAwareTransport struct {
+	h2 http.RoundTripper
+	h1 http.RoundTripper
+}
+
+func (t *upgradeAwareTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if isUpgradeRequest(r) {
+		return t.h1.RoundTrip(r)
+	}
+	return t.h2.RoundTrip(r)
+}
@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newUpgradeEchoBackend listens on a unix socket and answers every request
+// with a 101 Switching Protocols handshake, then echoes whatever bytes it
+// receives back to the caller. It stands in for wolf.sock in tests that
+// need to drive a real Upgrade handshake end to end.
+func newUpgradeEchoBackend(t *testing.T, socket string) {
+	t.Helper()
+
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen on %s: %v", socket, err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				br := bufio.NewReader(conn)
+				if _, err := http.ReadRequest(br); err != nil {
+					return
+				}
+
+				io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+
+				buf := make([]byte, 4096)
+				for {
+					n, err := br.Read(buf)
+					if n > 0 {
+						if _, writeErr := conn.Write(buf[:n]); writeErr != nil {
+							return
+						}
+					}
+					if err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+}
+
+// recordedRequest captures the path and raw query a recordingBackend saw.
+type recordedRequest struct {
+	path, rawQuery string
+}
+
+// newRecordingBackend listens on a unix socket and records the path and
+// raw query of the last request it received, replying 200 OK to each.
+func newRecordingBackend(t *testing.T, socket string) *recordedRequest {
+	t.Helper()
+
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen on %s: %v", socket, err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	got := &recordedRequest{}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path = r.URL.Path
+		got.rawQuery = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+	})}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	return got
+}
+
+func TestNewWolfProxy_PreservesPathAndRawQuery(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "wolf.sock")
+	got := newRecordingBackend(t, socket)
+
+	frontend := httptest.NewServer(NewWolfProxy(socket, WithHTTP2(false)))
+	t.Cleanup(frontend.Close)
+
+	const path = "/api/v1/sessions/1/../2"
+	const rawQuery = "foo=bar&baz=qux"
+	resp, err := http.Get(frontend.URL + path + "?" + rawQuery)
+	if err != nil {
+		t.Fatalf("GET through proxy: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if got.path != path {
+		t.Fatalf("expected backend to see path %q, got %q", path, got.path)
+	}
+	if got.rawQuery != rawQuery {
+		t.Fatalf("expected backend to see raw query %q, got %q", rawQuery, got.rawQuery)
+	}
+}
+
+func TestNewWolfProxy_UpgradeHandshake(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "wolf.sock")
+	newUpgradeEchoBackend(t, socket)
+
+	frontend := httptest.NewServer(NewWolfProxy(socket, WithHTTP2(true)))
+	t.Cleanup(frontend.Close)
+
+	conn, err := net.DialTimeout("tcp", frontend.Listener.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("dial frontend: %v", err)
+	}
+	defer conn.Close()
+
+	req := "GET /api/v1/sessions/1/input HTTP/1.1\r\n" +
+		"Host: wolf-agent\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Upgrade: websocket\r\n" +
+		"\r\n"
+	if _, err := io.WriteString(conn, req); err != nil {
+		t.Fatalf("write upgrade request: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("read upgrade response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101 Switching Protocols, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Upgrade"); got != "websocket" {
+		t.Fatalf("expected Upgrade: websocket, got %q", got)
+	}
+
+	if _, err := io.WriteString(conn, "ping"); err != nil {
+		t.Fatalf("write payload: %v", err)
+	}
+	echoed := make([]byte, 4)
+	if _, err := io.ReadFull(br, echoed); err != nil {
+		t.Fatalf("read echoed payload: %v", err)
+	}
+	if string(echoed) != "ping" {
+		t.Fatalf("expected echoed %q, got %q", "ping", echoed)
+	}
+}